@@ -0,0 +1,127 @@
+// Copyright 2018 The Nakama Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+func TestMatchEventBusFiltersByMatchID(t *testing.T) {
+	bus := NewMatchEventBus(zap.NewNop())
+	matchID := uuid.Must(uuid.NewV4())
+	otherID := uuid.Must(uuid.NewV4())
+	mh := &MatchHandler{ID: matchID, Label: atomic.NewString("")}
+
+	ch, unsubscribe := bus.Subscribe(EventFilter{MatchID: otherID})
+	defer unsubscribe()
+
+	bus.Publish(mh, MatchEvent{Type: MatchEventTick, MatchID: matchID})
+
+	select {
+	case ev := <-ch:
+		t.Fatalf("expected no event for non-matching filter, got %+v", ev)
+	default:
+	}
+}
+
+func TestMatchEventBusDropsSlowSubscriber(t *testing.T) {
+	bus := NewMatchEventBus(zap.NewNop())
+	mh := &MatchHandler{ID: uuid.Must(uuid.NewV4()), Label: atomic.NewString("")}
+
+	ch, _ := bus.Subscribe(EventFilter{})
+	for i := 0; i < matchEventSubscriberQueueSize+1; i++ {
+		bus.Publish(mh, MatchEvent{Type: MatchEventTick, MatchID: mh.ID})
+	}
+
+	if _, ok := <-ch; !ok {
+		return
+	}
+
+	// Drain until the channel is closed to confirm the slow subscriber was dropped.
+	for range ch {
+	}
+}
+
+func TestMatchRegistryEventHubFansOutAcrossMatches(t *testing.T) {
+	hub := NewMatchRegistryEventHub()
+	mh1 := &MatchHandler{ID: uuid.Must(uuid.NewV4()), Label: atomic.NewString(""), eventBus: NewMatchEventBus(zap.NewNop())}
+	mh2 := &MatchHandler{ID: uuid.Must(uuid.NewV4()), Label: atomic.NewString(""), eventBus: NewMatchEventBus(zap.NewNop())}
+	hub.Track(mh1)
+	hub.Track(mh2)
+
+	ch, unsubscribe := hub.Subscribe(EventFilter{})
+	defer unsubscribe()
+
+	mh1.eventBus.Publish(mh1, MatchEvent{Type: MatchEventTick, MatchID: mh1.ID})
+	mh2.eventBus.Publish(mh2, MatchEvent{Type: MatchEventTick, MatchID: mh2.ID})
+
+	seen := map[uuid.UUID]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-ch:
+			seen[ev.MatchID] = true
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for fanned-out event %d", i)
+		}
+	}
+
+	if !seen[mh1.ID] || !seen[mh2.ID] {
+		t.Fatalf("expected events from both tracked matches, got %+v", seen)
+	}
+}
+
+func TestMatchRegistryEventHubWiresInMatchesTrackedAfterSubscribe(t *testing.T) {
+	hub := NewMatchRegistryEventHub()
+
+	ch, unsubscribe := hub.Subscribe(EventFilter{LabelPrefix: "arena-"})
+	defer unsubscribe()
+
+	mh := &MatchHandler{ID: uuid.Must(uuid.NewV4()), Label: atomic.NewString("arena-1"), eventBus: NewMatchEventBus(zap.NewNop())}
+	hub.Track(mh)
+
+	mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventTick, MatchID: mh.ID})
+
+	select {
+	case ev := <-ch:
+		if ev.MatchID != mh.ID {
+			t.Fatalf("expected event from %v, got %+v", mh.ID, ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for event from match tracked after Subscribe")
+	}
+}
+
+func TestMatchRegistryEventHubRespectsFilterAndUntrack(t *testing.T) {
+	hub := NewMatchRegistryEventHub()
+	mh := &MatchHandler{ID: uuid.Must(uuid.NewV4()), Label: atomic.NewString(""), eventBus: NewMatchEventBus(zap.NewNop())}
+	hub.Track(mh)
+	hub.Untrack(mh.ID)
+
+	ch, unsubscribe := hub.Subscribe(EventFilter{MatchID: mh.ID})
+	defer unsubscribe()
+
+	mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventTick, MatchID: mh.ID})
+
+	select {
+	case ev := <-ch:
+		t.Fatalf("expected no event for untracked match, got %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -17,6 +17,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"sync"
 	"time"
 
@@ -26,58 +27,148 @@ import (
 	"go.uber.org/zap"
 )
 
-type MatchPresenceList struct {
+// defaultMatchPresenceListShards is used whenever the configured shard count is missing or invalid.
+const defaultMatchPresenceListShards = 16
+
+// matchPresenceListShard holds one bucket of the sharded presence list, along with a secondary
+// index by UserID so match handlers can answer "is this user in the match" without a full scan.
+type matchPresenceListShard struct {
 	sync.RWMutex
-	presences []*PresenceID
+	presences map[PresenceID]*MatchPresence
+	byUserID  map[uuid.UUID]map[PresenceID]*MatchPresence
+}
+
+// MatchPresenceList tracks the presences currently joined to a match. It shards presences across
+// N buckets keyed by a hash of SessionID to avoid a single full-list lock on every join, leave,
+// and containment check.
+type MatchPresenceList struct {
+	shardCount uint32
+	shards     []*matchPresenceListShard
+}
+
+func NewMatchPresenceList(shardCount int) *MatchPresenceList {
+	if shardCount <= 0 {
+		shardCount = defaultMatchPresenceListShards
+	}
+
+	shards := make([]*matchPresenceListShard, shardCount)
+	for i := range shards {
+		shards[i] = &matchPresenceListShard{
+			presences: make(map[PresenceID]*MatchPresence),
+			byUserID:  make(map[uuid.UUID]map[PresenceID]*MatchPresence),
+		}
+	}
+
+	return &MatchPresenceList{
+		shardCount: uint32(shardCount),
+		shards:     shards,
+	}
+}
+
+func (m *MatchPresenceList) shardFor(sessionID uuid.UUID) *matchPresenceListShard {
+	h := fnv.New32a()
+	_, _ = h.Write(sessionID.Bytes())
+	return m.shards[h.Sum32()%m.shardCount]
 }
 
 func (m *MatchPresenceList) Join(joins []*MatchPresence) {
-	m.Lock()
 	for _, join := range joins {
-		m.presences = append(m.presences, &PresenceID{
+		presenceID := PresenceID{
 			Node:      join.Node,
 			SessionID: join.SessionID,
-		})
+		}
+		shard := m.shardFor(join.SessionID)
+
+		shard.Lock()
+		shard.presences[presenceID] = join
+		byUser, ok := shard.byUserID[join.UserID]
+		if !ok {
+			byUser = make(map[PresenceID]*MatchPresence)
+			shard.byUserID[join.UserID] = byUser
+		}
+		byUser[presenceID] = join
+		shard.Unlock()
 	}
-	m.Unlock()
 }
 
 func (m *MatchPresenceList) Leave(leaves []*MatchPresence) {
-	m.Lock()
 	for _, leave := range leaves {
-		for i, presenceID := range m.presences {
-			if presenceID.SessionID == leave.SessionID && presenceID.Node == leave.Node {
-				m.presences = append(m.presences[:i], m.presences[i+1:]...)
-				break
+		presenceID := PresenceID{
+			Node:      leave.Node,
+			SessionID: leave.SessionID,
+		}
+		shard := m.shardFor(leave.SessionID)
+
+		shard.Lock()
+		delete(shard.presences, presenceID)
+		if byUser, ok := shard.byUserID[leave.UserID]; ok {
+			delete(byUser, presenceID)
+			if len(byUser) == 0 {
+				delete(shard.byUserID, leave.UserID)
 			}
 		}
+		shard.Unlock()
 	}
-	m.Unlock()
 }
 
+// Contains reports whether the given presence, identified by Node and SessionID, is currently
+// part of the match.
 func (m *MatchPresenceList) Contains(presence *PresenceID) bool {
-	var found bool
-	m.RLock()
-	for _, p := range m.presences {
-		if p.SessionID == presence.SessionID && p.Node == p.Node {
-			found = true
-			break
+	shard := m.shardFor(presence.SessionID)
+
+	shard.RLock()
+	_, found := shard.presences[*presence]
+	shard.RUnlock()
+
+	return found
+}
+
+// ContainsUserID reports whether any presence belonging to the given user is currently part of
+// the match, without needing to know the specific session or node.
+func (m *MatchPresenceList) ContainsUserID(userID uuid.UUID) bool {
+	for _, shard := range m.shards {
+		shard.RLock()
+		_, found := shard.byUserID[userID]
+		shard.RUnlock()
+		if found {
+			return true
 		}
 	}
-	m.RUnlock()
-	return found
+	return false
 }
 
 func (m *MatchPresenceList) List() []*PresenceID {
-	m.RLock()
-	list := make([]*PresenceID, 0, len(m.presences))
-	for _, presence := range m.presences {
-		list = append(list, presence)
-	}
-	m.RUnlock()
+	list := make([]*PresenceID, 0)
+	m.Iterate(func(presence *MatchPresence) bool {
+		list = append(list, &PresenceID{
+			Node:      presence.Node,
+			SessionID: presence.SessionID,
+		})
+		return true
+	})
 	return list
 }
 
+// Iterate snapshots each shard under RLock in turn and invokes fn for every presence found.
+// Returning false from fn stops iteration early. Because each shard is copied before fn is
+// called, fn may safely call back into the presence list (e.g. Contains) without deadlocking.
+func (m *MatchPresenceList) Iterate(fn func(presence *MatchPresence) bool) {
+	for _, shard := range m.shards {
+		shard.RLock()
+		snapshot := make([]*MatchPresence, 0, len(shard.presences))
+		for _, presence := range shard.presences {
+			snapshot = append(snapshot, presence)
+		}
+		shard.RUnlock()
+
+		for _, presence := range snapshot {
+			if !fn(presence) {
+				return
+			}
+		}
+	}
+}
+
 type MatchDataMessage struct {
 	UserID      uuid.UUID
 	SessionID   uuid.UUID
@@ -125,8 +216,10 @@ type MatchHandler struct {
 	tracker       Tracker
 	router        MessageRouter
 
-	presenceList *MatchPresenceList
-	core         RuntimeMatchCore
+	presenceList  *MatchPresenceList
+	core          RuntimeMatchCore
+	eventBus      *MatchEventBus
+	snapshotStore *MatchSnapshotStore
 
 	// Identification not (directly) controlled by match init.
 	ID     uuid.UUID
@@ -142,44 +235,151 @@ type MatchHandler struct {
 	ticker        *time.Ticker
 	callCh        chan func(*MatchHandler)
 	joinAttemptCh chan func(*MatchHandler)
-	stopCh        chan struct{}
+	ctx           context.Context
+	ctxCancelFn   context.CancelFunc
 	stopped       *atomic.Bool
 
 	// Configuration set by match init.
 	Label *atomic.String
-	Rate  int
+
+	// Rate is the match's current tick rate. It is mutated from the match's own goroutine by
+	// checkBackpressure, but read from QueueMetrics by whatever goroutine polls the metrics
+	// subsystem, so it must be atomic rather than a bare int.
+	Rate *atomic.Int64
+
+	// baseRate is the tick rate match init asked for; Rate may be temporarily lowered by
+	// adaptive backpressure and is always restored towards baseRate as pressure eases. Only ever
+	// touched from the match's own goroutine.
+	baseRate int
+
+	// rateFloor is the slowest Rate is ever allowed to drop to under backpressure. Resolved once
+	// at construction by resolveMatchBackpressureRateFloor; only ever touched from the match's own
+	// goroutine.
+	rateFloor int64
+
+	// snapshotRate is the number of ticks between durable snapshots of this match's state, as
+	// returned by MatchInit, or as carried in the snapshot record on restore. 0 disables
+	// snapshotting.
+	snapshotRate int
+
+	// overflow holds the per-queue overflow policy selected at match init.
+	overflow MatchQueueOverflowConfig
+
+	// coalesceMu serialises every push to mh.inputCh under OverflowCoalesce: QueueData's direct
+	// push and coalesceInput's drain/merge/requeue sequence. QueueData (and so coalesceInput) is
+	// called concurrently from many producer goroutines, one per session, so without this two
+	// overlapping calls could each drain a disjoint subset of the queue, split a same-session/opcode
+	// pair across them, or refill it mid-coalesce, and have one of the resulting entries dropped
+	// once the re-pushed totals exceed capacity - exactly the loss OverflowCoalesce exists to avoid.
+	coalesceMu sync.Mutex
+
+	// Backpressure tracking for the call queue, only ever touched from the match's own goroutine.
+	highWaterTicks int
+	lowWaterTicks  int
+	backpressured  bool
+
+	// Queue metrics, exported through MatchHandler.QueueMetrics.
+	callDrops  *atomic.Int64
+	inputDrops *atomic.Int64
+	coalesces  *atomic.Int64
 
 	// Match state.
 	state interface{}
 }
 
-func NewMatchHandler(logger *zap.Logger, config Config, matchRegistry MatchRegistry, core RuntimeMatchCore, label *atomic.String, id uuid.UUID, node string, params map[string]interface{}) (*MatchHandler, error) {
-	presenceList := &MatchPresenceList{
-		presences: make([]*PresenceID, 0, 10),
-	}
+func NewMatchHandler(logger *zap.Logger, config Config, matchRegistry MatchRegistry, core RuntimeMatchCore, snapshotStore *MatchSnapshotStore, parentCtx context.Context, label *atomic.String, id uuid.UUID, node string, params map[string]interface{}) (*MatchHandler, error) {
+	presenceList := NewMatchPresenceList(config.GetMatch().PresenceListShards)
+	ctx, ctxCancelFn := context.WithCancel(parentCtx)
 
-	state, rateInt, labelStr, err := core.MatchInit(presenceList, params)
+	state, rateInt, labelStr, snapshotRate, overflow, err := core.MatchInit(ctx, presenceList, params)
 	if err != nil {
 		core.Cancel()
+		ctxCancelFn()
 		return nil, err
 	}
 	if state == nil {
 		core.Cancel()
+		ctxCancelFn()
 		return nil, errors.New("Match initial state must not be nil")
 	}
 	err = matchRegistry.UpdateMatchLabel(id, labelStr)
 	if err != nil {
+		ctxCancelFn()
 		return nil, err
 	}
 	label.Store(labelStr)
 
-	// Construct the match.
+	mh := buildMatchHandler(logger, config, matchRegistry, core, snapshotStore, presenceList, ctx, ctxCancelFn, label, id, node, state, rateInt, snapshotRate, 0, overflow)
+
+	mh.logger.Info("Match started")
+	mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventStarted, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick})
+
+	return mh, nil
+}
+
+// RestoreMatchHandler recreates a match handler from a previously persisted snapshot record.
+// Presences are never restored: the match comes back empty, and immediately receives a synthetic
+// MatchTerminate-style callback so game code can decide whether to keep the restored state and
+// wait for players to rejoin, or give up and let the match end.
+func RestoreMatchHandler(logger *zap.Logger, config Config, matchRegistry MatchRegistry, core RuntimeMatchCore, snapshotStore *MatchSnapshotStore, parentCtx context.Context, id uuid.UUID, node string, record *MatchSnapshotRecord) (*MatchHandler, error) {
+	presenceList := NewMatchPresenceList(config.GetMatch().PresenceListShards)
+	ctx, ctxCancelFn := context.WithCancel(parentCtx)
+
+	state, rateInt, labelStr, err := core.MatchRestore(record.State)
+	if err != nil {
+		core.Cancel()
+		ctxCancelFn()
+		return nil, err
+	}
+	if state == nil {
+		core.Cancel()
+		ctxCancelFn()
+		return nil, errors.New("Restored match state must not be nil")
+	}
+	if labelStr == "" {
+		labelStr = record.Label
+	}
+	err = matchRegistry.UpdateMatchLabel(id, labelStr)
+	if err != nil {
+		ctxCancelFn()
+		return nil, err
+	}
+	label := atomic.NewString(labelStr)
+
+	mh := buildMatchHandler(logger, config, matchRegistry, core, snapshotStore, presenceList, ctx, ctxCancelFn, label, id, node, state, rateInt, record.Rate, record.Tick, nil)
+
+	mh.logger.Info("Match restored from snapshot", zap.Int64("tick", mh.tick))
+	mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventStarted, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick})
+
+	// Presences never survive a restore. Route through the normal terminate callback so game code
+	// decides what happens next: returning nil state aborts the match, anything else keeps it
+	// alive to wait for players to rejoin. matchRestoreTerminateGraceSeconds must be non-zero: a
+	// grace period of 0 tells QueueTerminate's callback to stop the match the instant it returns,
+	// regardless of what MatchTerminate decided, which would defeat the point of restoring state
+	// at all.
+	mh.QueueTerminate(matchRestoreTerminateGraceSeconds)
+
+	return mh, nil
+}
+
+// matchRestoreTerminateGraceSeconds is the grace period passed to the synthetic MatchTerminate
+// callback fired immediately after a restore. See the comment in RestoreMatchHandler for why this
+// must stay non-zero.
+const matchRestoreTerminateGraceSeconds = 1
+
+// buildMatchHandler assembles and starts the goroutine backing a MatchHandler. It is shared by
+// NewMatchHandler and RestoreMatchHandler, which differ only in how they obtain the initial
+// state, tick rate, snapshot rate, label, starting tick, and queue overflow policy (a restored
+// match always uses the default overflow policy).
+func buildMatchHandler(logger *zap.Logger, config Config, matchRegistry MatchRegistry, core RuntimeMatchCore, snapshotStore *MatchSnapshotStore, presenceList *MatchPresenceList, ctx context.Context, ctxCancelFn context.CancelFunc, label *atomic.String, id uuid.UUID, node string, state interface{}, rateInt, snapshotRate int, startTick int64, overflow *MatchQueueOverflowConfig) *MatchHandler {
 	mh := &MatchHandler{
 		logger:        logger,
 		matchRegistry: matchRegistry,
 
-		presenceList: presenceList,
-		core:         core,
+		presenceList:  presenceList,
+		core:          core,
+		eventBus:      NewMatchEventBus(logger),
+		snapshotStore: snapshotStore,
 
 		ID:    id,
 		Node:  node,
@@ -190,29 +390,38 @@ func NewMatchHandler(logger *zap.Logger, config Config, matchRegistry MatchRegis
 			Label:   node,
 		},
 
-		tick: 0,
+		tick: startTick,
 
 		inputCh: make(chan *MatchDataMessage, config.GetMatch().InputQueueSize),
 		// Ticker below.
 		callCh:        make(chan func(mh *MatchHandler), config.GetMatch().CallQueueSize),
 		joinAttemptCh: make(chan func(mh *MatchHandler), config.GetMatch().JoinAttemptQueueSize),
-		stopCh:        make(chan struct{}),
+		ctx:           ctx,
+		ctxCancelFn:   ctxCancelFn,
 		stopped:       atomic.NewBool(false),
 
-		Label: label,
-		Rate:  rateInt,
+		Label:        label,
+		Rate:         atomic.NewInt64(int64(rateInt)),
+		baseRate:     rateInt,
+		rateFloor:    resolveMatchBackpressureRateFloor(config),
+		snapshotRate: snapshotRate,
+		overflow:     resolveMatchQueueOverflowConfig(logger, overflow),
+
+		callDrops:  atomic.NewInt64(0),
+		inputDrops: atomic.NewInt64(0),
+		coalesces:  atomic.NewInt64(0),
 
 		state: state,
 	}
 
 	// Set up the ticker that governs the match loop.
-	mh.ticker = time.NewTicker(time.Second / time.Duration(mh.Rate))
+	mh.ticker = time.NewTicker(time.Second / time.Duration(mh.Rate.Load()))
 
 	// Continuously run queued actions until the match stops.
 	go func() {
 		for {
 			select {
-			case <-mh.stopCh:
+			case <-mh.ctx.Done():
 				// Match has been stopped.
 				return
 			case <-mh.ticker.C:
@@ -230,9 +439,19 @@ func NewMatchHandler(logger *zap.Logger, config Config, matchRegistry MatchRegis
 		}
 	}()
 
-	mh.logger.Info("Match started")
+	return mh
+}
 
-	return mh, nil
+// Subscribe registers a listener for this match's activity. See MatchEventBus.Subscribe.
+func (mh *MatchHandler) Subscribe(filter EventFilter) (<-chan MatchEvent, func()) {
+	return mh.eventBus.Subscribe(filter)
+}
+
+// UpdateLabel is invoked when the runtime changes the match label mid-match (outside of
+// MatchInit), and publishes a MatchLabelChanged event for any subscribers.
+func (mh *MatchHandler) UpdateLabel(label string) {
+	mh.Label.Store(label)
+	mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventLabelChanged, MatchID: mh.ID, Node: mh.Node, Label: label, Tick: mh.tick})
 }
 
 // Used when an internal match process (or error) requires it to stop.
@@ -247,12 +466,23 @@ func (mh *MatchHandler) Close() {
 		return
 	}
 	mh.core.Cancel()
-	close(mh.stopCh)
+	mh.ctxCancelFn()
 	mh.ticker.Stop()
+
+	// A deliberate close means this match is done for good, so its last snapshot (if any) is no
+	// longer a legitimate recovery candidate. Remove it so a server restart within the staleness
+	// window doesn't resurrect an already-finished match as a zombie. A process killed before this
+	// runs still leaves the snapshot in place, which is the crash-recovery case RestoreMatches
+	// exists for.
+	if mh.snapshotStore != nil {
+		if err := mh.snapshotStore.Delete(mh.IDStr); err != nil {
+			mh.logger.Warn("Error deleting match snapshot on close", zap.Error(err))
+		}
+	}
 }
 
 func (mh *MatchHandler) queueCall(f func(*MatchHandler)) bool {
-	if mh.stopped.Load() {
+	if mh.ctx.Err() != nil {
 		return false
 	}
 
@@ -260,7 +490,44 @@ func (mh *MatchHandler) queueCall(f func(*MatchHandler)) bool {
 	case mh.callCh <- f:
 		return true
 	default:
-		// Match call queue is full, the handler isn't processing fast enough.
+	}
+
+	// Match call queue is full, the handler isn't processing fast enough. What happens next
+	// depends on the overflow policy selected at match init.
+	switch mh.overflow.Call.Kind {
+	case OverflowDrop:
+		mh.callDrops.Inc()
+		mh.logger.Warn("Match call queue full, dropping call")
+		return false
+	case OverflowDropOldest:
+		select {
+		case <-mh.callCh:
+			mh.callDrops.Inc()
+		default:
+		}
+		select {
+		case mh.callCh <- f:
+			return true
+		default:
+			mh.callDrops.Inc()
+			mh.logger.Warn("Match call queue full, dropping call after evicting oldest")
+			return false
+		}
+	case OverflowBlock:
+		timer := time.NewTimer(mh.overflow.Call.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case mh.callCh <- f:
+			return true
+		case <-timer.C:
+			mh.callDrops.Inc()
+			mh.logger.Warn("Match call queue full, timed out waiting to enqueue call")
+			return false
+		case <-mh.ctx.Done():
+			return false
+		}
+	default:
+		// OverflowCloseMatch, and OverflowCoalesce which doesn't apply to the call queue.
 		mh.logger.Warn("Match handler call processing too slow, closing match")
 		mh.Stop()
 		return false
@@ -268,26 +535,133 @@ func (mh *MatchHandler) queueCall(f func(*MatchHandler)) bool {
 }
 
 func (mh *MatchHandler) QueueData(m *MatchDataMessage) {
-	if mh.stopped.Load() {
+	if mh.ctx.Err() != nil {
 		return
 	}
 
+	if mh.overflow.Input.Kind == OverflowCoalesce {
+		// Every push to mh.inputCh happens under coalesceMu, so no producer can refill the queue
+		// while coalesceInput has it drained.
+		mh.coalesceMu.Lock()
+		defer mh.coalesceMu.Unlock()
+	}
+
 	select {
 	case mh.inputCh <- m:
+		mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventDataReceived, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick, Data: m})
 		return
 	default:
-		// Match input queue is full, the handler isn't processing fast enough or there's too much incoming data.
+	}
+
+	// Match input queue is full, the handler isn't processing fast enough or there's too much
+	// incoming data. What happens next depends on the overflow policy selected at match init.
+	switch mh.overflow.Input.Kind {
+	case OverflowDropOldest:
+		select {
+		case <-mh.inputCh:
+			mh.inputDrops.Inc()
+		default:
+		}
+		select {
+		case mh.inputCh <- m:
+			mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventDataReceived, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick, Data: m})
+		default:
+			mh.inputDrops.Inc()
+			mh.logger.Warn("Match input queue full, dropping data message after evicting oldest")
+		}
+	case OverflowBlock:
+		timer := time.NewTimer(mh.overflow.Input.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case mh.inputCh <- m:
+			mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventDataReceived, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick, Data: m})
+		case <-timer.C:
+			mh.inputDrops.Inc()
+			mh.logger.Warn("Match input queue full, timed out waiting to enqueue data message")
+		case <-mh.ctx.Done():
+		}
+	case OverflowCoalesce:
+		mh.coalesceInput(m)
+	case OverflowCloseMatch:
+		mh.logger.Warn("Match input queue full, closing match")
+		mh.Stop()
+	default:
+		// OverflowDrop.
 		mh.logger.Warn("Match handler data processing too slow, dropping data message")
-		return
+		mh.inputDrops.Inc()
+	}
+}
+
+// coalesceInput is used when the input queue's overflow policy is OverflowCoalesce. It drains the
+// currently queued messages, merges m into the first same-opcode, same-session message found
+// using the runtime-supplied merge function, and puts everything back. This only runs once the
+// queue is already full, so it never adds overhead to the common case.
+//
+// QueueData, the only caller, is designed to be called concurrently from many producer goroutines
+// (one per session), so QueueData holds coalesceMu across its own direct push and this whole
+// drain/merge/requeue sequence: otherwise two overlapping calls could each drain a disjoint subset
+// of mh.inputCh, split a same-session/opcode pair across them, or refill the drained queue, and
+// lose one of the resulting entries to the capacity guard below.
+func (mh *MatchHandler) coalesceInput(m *MatchDataMessage) {
+	pending := make([]*MatchDataMessage, 0, len(mh.inputCh)+1)
+drain:
+	for {
+		select {
+		case existing := <-mh.inputCh:
+			pending = append(pending, existing)
+		default:
+			break drain
+		}
+	}
+
+	merged := false
+	for i, existing := range pending {
+		if existing.SessionID == m.SessionID && existing.OpCode == m.OpCode {
+			pending[i] = mh.overflow.Input.Coalesce(existing, m)
+			merged = true
+			mh.coalesces.Inc()
+			break
+		}
+	}
+	if !merged {
+		pending = append(pending, m)
+	}
+
+	for _, p := range pending {
+		select {
+		case mh.inputCh <- p:
+		default:
+			// The queue was already at capacity and we coalesced rather than grew it, so this
+			// should not happen; guard against it regardless rather than blocking.
+			mh.inputDrops.Inc()
+			mh.logger.Warn("Match input queue full after coalescing, dropping data message")
+		}
+	}
+
+	mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventDataReceived, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick, Data: m})
+}
+
+// QueueMetrics returns a point-in-time snapshot of this match's queue depths and lifetime
+// counters, for export through the metrics subsystem.
+func (mh *MatchHandler) QueueMetrics() MatchQueueMetrics {
+	return MatchQueueMetrics{
+		CallDepth:  len(mh.callCh),
+		CallCap:    cap(mh.callCh),
+		CallDrops:  mh.callDrops.Load(),
+		InputDepth: len(mh.inputCh),
+		InputCap:   cap(mh.inputCh),
+		InputDrops: mh.inputDrops.Load(),
+		Coalesces:  mh.coalesces.Load(),
+		TickRate:   int(mh.Rate.Load()),
 	}
 }
 
 func loop(mh *MatchHandler) {
-	if mh.stopped.Load() {
+	if mh.ctx.Err() != nil {
 		return
 	}
 
-	state, err := mh.core.MatchLoop(mh.tick, mh.state, mh.inputCh)
+	state, err := mh.core.MatchLoop(mh.ctx, mh.tick, mh.state, mh.inputCh)
 	if err != nil {
 		mh.Stop()
 		mh.logger.Warn("Stopping match after error from match_loop execution", zap.Int64("tick", mh.tick), zap.Error(err))
@@ -301,10 +675,112 @@ func loop(mh *MatchHandler) {
 
 	mh.state = state
 	mh.tick++
+	mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventTick, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick})
+
+	if mh.snapshotRate > 0 && mh.tick%int64(mh.snapshotRate) == 0 {
+		mh.snapshot()
+	}
+
+	mh.checkBackpressure()
+}
+
+// Backpressure thresholds for the call queue, expressed as a fraction of its capacity.
+const (
+	matchBackpressureHighWaterRatio = 0.75
+	matchBackpressureLowWaterRatio  = 0.25
+	// matchBackpressureWindow is how many consecutive ticks occupancy must stay past a water
+	// mark before the tick rate is adjusted, to avoid reacting to brief spikes.
+	matchBackpressureWindow = 5
+	// defaultMatchBackpressureRateFloor is the slowest the adaptive tick rate is ever allowed to
+	// go, used whenever the configured floor is missing or invalid.
+	defaultMatchBackpressureRateFloor = 1
+)
+
+// resolveMatchBackpressureRateFloor returns the per-match backpressure rate floor from config,
+// falling back to defaultMatchBackpressureRateFloor if it isn't set to a positive value.
+func resolveMatchBackpressureRateFloor(config Config) int64 {
+	floor := config.GetMatch().BackpressureRateFloor
+	if floor <= 0 {
+		floor = defaultMatchBackpressureRateFloor
+	}
+	return int64(floor)
+}
+
+// checkBackpressure halves the match's tick rate when the call queue has been under sustained
+// pressure for matchBackpressureWindow consecutive ticks, down to mh.rateFloor, and restores it
+// towards baseRate once pressure has eased for just as long.
+func (mh *MatchHandler) checkBackpressure() {
+	occupancy := float64(len(mh.callCh)) / float64(cap(mh.callCh))
+
+	if occupancy >= matchBackpressureHighWaterRatio {
+		mh.lowWaterTicks = 0
+		mh.highWaterTicks++
+
+		if mh.highWaterTicks >= matchBackpressureWindow && mh.Rate.Load() > mh.rateFloor {
+			rate := mh.Rate.Load() / 2
+			if rate < mh.rateFloor {
+				rate = mh.rateFloor
+			}
+			mh.Rate.Store(rate)
+			mh.ticker.Reset(time.Second / time.Duration(rate))
+			mh.backpressured = true
+			mh.highWaterTicks = 0
+
+			mh.logger.Warn("Match call queue under sustained pressure, lowering tick rate", zap.Int64("rate", rate))
+			mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventBackpressure, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick, TickRate: int(rate)})
+		}
+		return
+	}
+
+	mh.highWaterTicks = 0
+
+	if !mh.backpressured || occupancy > matchBackpressureLowWaterRatio {
+		mh.lowWaterTicks = 0
+		return
+	}
+
+	mh.lowWaterTicks++
+	if mh.lowWaterTicks >= matchBackpressureWindow {
+		rate := mh.Rate.Load() * 2
+		if rate >= int64(mh.baseRate) {
+			rate = int64(mh.baseRate)
+			mh.backpressured = false
+		}
+		mh.Rate.Store(rate)
+		mh.ticker.Reset(time.Second / time.Duration(rate))
+		mh.lowWaterTicks = 0
+
+		mh.logger.Info("Match call queue pressure relieved, restoring tick rate", zap.Int64("rate", rate))
+	}
+}
+
+// snapshot serialises the match's current state to the snapshot store, if one is configured. A
+// failure here is logged and otherwise ignored: it must never take down an in-progress match.
+func (mh *MatchHandler) snapshot() {
+	if mh.snapshotStore == nil {
+		return
+	}
+
+	data, err := mh.core.MatchSnapshot(mh.state)
+	if err != nil {
+		mh.logger.Warn("Error snapshotting match state", zap.Int64("tick", mh.tick), zap.Error(err))
+		return
+	}
+
+	record := &MatchSnapshotRecord{
+		State:   data,
+		Tick:    mh.tick,
+		Label:   mh.Label.Load(),
+		Rate:    mh.snapshotRate,
+		SavedAt: time.Now().Unix(),
+	}
+	if err := mh.snapshotStore.Save(mh.IDStr, record); err != nil {
+		mh.logger.Warn("Error persisting match snapshot", zap.Int64("tick", mh.tick), zap.Error(err))
+	}
 }
 
 func (mh *MatchHandler) QueueJoinAttempt(ctx context.Context, resultCh chan *MatchJoinResult, userID, sessionID uuid.UUID, username, node string, metadata map[string]string) bool {
-	if mh.stopped.Load() {
+	if mh.ctx.Err() != nil {
 		return false
 	}
 
@@ -318,12 +794,12 @@ func (mh *MatchHandler) QueueJoinAttempt(ctx context.Context, resultCh chan *Mat
 		default:
 		}
 
-		if mh.stopped.Load() {
+		if mh.ctx.Err() != nil {
 			resultCh <- &MatchJoinResult{Allow: false}
 			return
 		}
 
-		state, allow, reason, err := mh.core.MatchJoinAttempt(mh.tick, mh.state, userID, sessionID, username, node, metadata)
+		state, allow, reason, err := mh.core.MatchJoinAttempt(mh.ctx, mh.tick, mh.state, userID, sessionID, username, node, metadata)
 		if err != nil {
 			mh.Stop()
 			mh.logger.Warn("Stopping match after error from match_join_attempt execution", zap.Int64("tick", mh.tick), zap.Error(err))
@@ -353,18 +829,18 @@ func (mh *MatchHandler) QueueJoinAttempt(ctx context.Context, resultCh chan *Mat
 }
 
 func (mh *MatchHandler) QueueJoin(joins []*MatchPresence) bool {
-	if mh.stopped.Load() {
+	if mh.ctx.Err() != nil {
 		return false
 	}
 
 	join := func(mh *MatchHandler) {
-		if mh.stopped.Load() {
+		if mh.ctx.Err() != nil {
 			return
 		}
 
 		mh.presenceList.Join(joins)
 
-		state, err := mh.core.MatchJoin(mh.tick, mh.state, joins)
+		state, err := mh.core.MatchJoin(mh.ctx, mh.tick, mh.state, joins)
 		if err != nil {
 			mh.Stop()
 			mh.logger.Warn("Stopping match after error from match_join execution", zap.Int64("tick", mh.tick), zap.Error(err))
@@ -377,24 +853,25 @@ func (mh *MatchHandler) QueueJoin(joins []*MatchPresence) bool {
 		}
 
 		mh.state = state
+		mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventPresenceJoin, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick, Presences: joins})
 	}
 
 	return mh.queueCall(join)
 }
 
 func (mh *MatchHandler) QueueLeave(leaves []*MatchPresence) bool {
-	if mh.stopped.Load() {
+	if mh.ctx.Err() != nil {
 		return false
 	}
 
 	leave := func(mh *MatchHandler) {
-		if mh.stopped.Load() {
+		if mh.ctx.Err() != nil {
 			return
 		}
 
 		mh.presenceList.Leave(leaves)
 
-		state, err := mh.core.MatchLeave(mh.tick, mh.state, leaves)
+		state, err := mh.core.MatchLeave(mh.ctx, mh.tick, mh.state, leaves)
 		if err != nil {
 			mh.Stop()
 			mh.logger.Warn("Stopping match after error from match_leave execution", zap.Int("tick", int(mh.tick)), zap.Error(err))
@@ -407,22 +884,23 @@ func (mh *MatchHandler) QueueLeave(leaves []*MatchPresence) bool {
 		}
 
 		mh.state = state
+		mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventPresenceLeave, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick, Presences: leaves})
 	}
 
 	return mh.queueCall(leave)
 }
 
 func (mh *MatchHandler) QueueTerminate(graceSeconds int) bool {
-	if mh.stopped.Load() {
+	if mh.ctx.Err() != nil {
 		return false
 	}
 
 	terminate := func(mh *MatchHandler) {
-		if mh.stopped.Load() {
+		if mh.ctx.Err() != nil {
 			return
 		}
 
-		state, err := mh.core.MatchTerminate(mh.tick, mh.state, graceSeconds)
+		state, err := mh.core.MatchTerminate(mh.ctx, mh.tick, mh.state, graceSeconds)
 		if err != nil {
 			mh.Stop()
 			mh.logger.Warn("Stopping match after error from match_terminate execution", zap.Int("tick", int(mh.tick)), zap.Error(err))
@@ -435,6 +913,7 @@ func (mh *MatchHandler) QueueTerminate(graceSeconds int) bool {
 		}
 
 		mh.state = state
+		mh.eventBus.Publish(mh, MatchEvent{Type: MatchEventTerminated, MatchID: mh.ID, Node: mh.Node, Label: mh.Label.Load(), Tick: mh.tick})
 
 		// If grace period is 0 end the match immediately after the callback returns.
 		if graceSeconds == 0 {
@@ -0,0 +1,190 @@
+// Copyright 2018 The Nakama Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// newTestMatchHandler builds a minimal MatchHandler sufficient to exercise queueCall, QueueData,
+// and checkBackpressure directly, without going through NewMatchHandler/RestoreMatchHandler (which
+// need a full RuntimeMatchCore and Config neither of which this test cares about).
+func newTestMatchHandler(overflow MatchQueueOverflowConfig, callCap, inputCap, rate int) *MatchHandler {
+	return &MatchHandler{
+		logger:   zap.NewNop(),
+		eventBus: NewMatchEventBus(zap.NewNop()),
+
+		ID:    uuid.Must(uuid.NewV4()),
+		Label: atomic.NewString(""),
+
+		callCh:  make(chan func(*MatchHandler), callCap),
+		inputCh: make(chan *MatchDataMessage, inputCap),
+		ticker:  time.NewTicker(time.Hour),
+		ctx:     context.Background(),
+
+		Rate:      atomic.NewInt64(int64(rate)),
+		baseRate:  rate,
+		rateFloor: defaultMatchBackpressureRateFloor,
+		overflow:  overflow,
+
+		callDrops:  atomic.NewInt64(0),
+		inputDrops: atomic.NewInt64(0),
+		coalesces:  atomic.NewInt64(0),
+	}
+}
+
+func TestQueueCallDropOldestEvictsOldestOnOverflow(t *testing.T) {
+	mh := newTestMatchHandler(MatchQueueOverflowConfig{Call: OverflowPolicy{Kind: OverflowDropOldest}}, 1, 1, 10)
+
+	first := func(*MatchHandler) {}
+	second := func(*MatchHandler) {}
+
+	if !mh.queueCall(first) {
+		t.Fatalf("expected first call to enqueue into empty queue")
+	}
+	if !mh.queueCall(second) {
+		t.Fatalf("expected second call to evict the first and enqueue")
+	}
+	if mh.callDrops.Load() != 1 {
+		t.Fatalf("expected exactly one call drop from eviction, got %d", mh.callDrops.Load())
+	}
+	if len(mh.callCh) != 1 {
+		t.Fatalf("expected call queue to hold exactly the evicting call, got depth %d", len(mh.callCh))
+	}
+}
+
+func TestQueueCallBlockTimesOutWhenQueueStaysFull(t *testing.T) {
+	mh := newTestMatchHandler(MatchQueueOverflowConfig{Call: OverflowPolicy{Kind: OverflowBlock, BlockTimeout: 20 * time.Millisecond}}, 1, 1, 10)
+
+	if !mh.queueCall(func(*MatchHandler) {}) {
+		t.Fatalf("expected first call to enqueue into empty queue")
+	}
+
+	start := time.Now()
+	if mh.queueCall(func(*MatchHandler) {}) {
+		t.Fatalf("expected second call to time out, not enqueue")
+	}
+	if elapsed := time.Since(start); elapsed < mh.overflow.Call.BlockTimeout {
+		t.Fatalf("expected queueCall to wait out the block timeout, returned after %v", elapsed)
+	}
+	if mh.callDrops.Load() != 1 {
+		t.Fatalf("expected one call drop after the block timeout, got %d", mh.callDrops.Load())
+	}
+}
+
+func TestCoalesceInputMergesSameSessionOpcodeMessage(t *testing.T) {
+	merge := func(a, b *MatchDataMessage) *MatchDataMessage {
+		merged := *a
+		merged.Data = append(append([]byte{}, a.Data...), b.Data...)
+		return &merged
+	}
+	mh := newTestMatchHandler(MatchQueueOverflowConfig{Input: OverflowPolicy{Kind: OverflowCoalesce, Coalesce: merge}}, 1, 1, 10)
+
+	sessionID := uuid.Must(uuid.NewV4())
+	existing := &MatchDataMessage{SessionID: sessionID, OpCode: 1, Data: []byte("a")}
+	mh.QueueData(existing)
+
+	incoming := &MatchDataMessage{SessionID: sessionID, OpCode: 1, Data: []byte("b")}
+	mh.QueueData(incoming)
+
+	if len(mh.inputCh) != 1 {
+		t.Fatalf("expected the two same-session/opcode messages to merge into one, got depth %d", len(mh.inputCh))
+	}
+	merged := <-mh.inputCh
+	if string(merged.Data) != "ab" {
+		t.Fatalf("expected merged data %q, got %q", "ab", merged.Data)
+	}
+	if mh.coalesces.Load() != 1 {
+		t.Fatalf("expected one coalesce to be recorded, got %d", mh.coalesces.Load())
+	}
+}
+
+// TestCoalesceInputConcurrentProducersDoNotLoseMessages exercises QueueData's real usage pattern:
+// many session goroutines hitting a full, coalescing input queue at once. Without coalesceMu
+// serialising the drain/merge/requeue sequence, concurrent calls can each drain a disjoint subset
+// of the queue, split a same-session/opcode pair across them, and lose one of the resulting
+// entries - so this specifically needs to pass under -race.
+func TestCoalesceInputConcurrentProducersDoNotLoseMessages(t *testing.T) {
+	merge := func(a, b *MatchDataMessage) *MatchDataMessage {
+		merged := *a
+		merged.Data = append(append([]byte{}, a.Data...), b.Data...)
+		return &merged
+	}
+	mh := newTestMatchHandler(MatchQueueOverflowConfig{Input: OverflowPolicy{Kind: OverflowCoalesce, Coalesce: merge}}, 1, 1, 10)
+
+	sessionID := uuid.Must(uuid.NewV4())
+	mh.QueueData(&MatchDataMessage{SessionID: sessionID, OpCode: 1, Data: []byte{0}})
+
+	const producers = 20
+	var wg sync.WaitGroup
+	wg.Add(producers)
+	for i := 0; i < producers; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			mh.QueueData(&MatchDataMessage{SessionID: sessionID, OpCode: 1, Data: []byte{byte(i + 1)}})
+		}()
+	}
+	wg.Wait()
+
+	if depth := len(mh.inputCh); depth != 1 {
+		t.Fatalf("expected every concurrent message to coalesce into a single queued entry, got depth %d", depth)
+	}
+	merged := <-mh.inputCh
+	if got := len(merged.Data); got != producers+1 {
+		t.Fatalf("expected merged data to contain all %d contributions with none dropped, got %d bytes: %v", producers+1, got, merged.Data)
+	}
+}
+
+func TestCheckBackpressureLowersThenRestoresRate(t *testing.T) {
+	mh := newTestMatchHandler(MatchQueueOverflowConfig{}, 4, 1, 8)
+
+	// Fill the call queue past the high water mark and hold it there for the full window so the
+	// rate is halved.
+	for i := 0; i < 3; i++ {
+		mh.callCh <- func(*MatchHandler) {}
+	}
+	for i := 0; i < matchBackpressureWindow; i++ {
+		mh.checkBackpressure()
+	}
+	if rate := mh.Rate.Load(); rate != 4 {
+		t.Fatalf("expected rate to halve from 8 to 4 under sustained pressure, got %d", rate)
+	}
+	if !mh.backpressured {
+		t.Fatalf("expected handler to be marked backpressured")
+	}
+
+	// Drain the queue back under the low water mark and hold it there for the full window so the
+	// rate is restored.
+	for len(mh.callCh) > 0 {
+		<-mh.callCh
+	}
+	for i := 0; i < matchBackpressureWindow; i++ {
+		mh.checkBackpressure()
+	}
+	if rate := mh.Rate.Load(); rate != int64(mh.baseRate) {
+		t.Fatalf("expected rate to restore to baseRate %d once pressure eased, got %d", mh.baseRate, rate)
+	}
+	if mh.backpressured {
+		t.Fatalf("expected handler to no longer be marked backpressured")
+	}
+}
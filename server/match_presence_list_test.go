@@ -0,0 +1,84 @@
+// Copyright 2018 The Nakama Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+// TestMatchPresenceListCrossNodeSessionIDCollision ensures that two presences sharing the same
+// SessionID but joining from different nodes are tracked independently. The original slice-backed
+// implementation compared p.Node == p.Node instead of p.Node == presence.Node, so a presence from
+// node B was incorrectly reported as present when only node A's presence with the same SessionID
+// had joined.
+func TestMatchPresenceListCrossNodeSessionIDCollision(t *testing.T) {
+	sessionID := uuid.Must(uuid.NewV4())
+	userID := uuid.Must(uuid.NewV4())
+
+	list := NewMatchPresenceList(defaultMatchPresenceListShards)
+	list.Join([]*MatchPresence{
+		{
+			Node:      "node-a",
+			UserID:    userID,
+			SessionID: sessionID,
+			Username:  "alice",
+		},
+	})
+
+	if !list.Contains(&PresenceID{Node: "node-a", SessionID: sessionID}) {
+		t.Fatal("expected presence on node-a to be found")
+	}
+	if list.Contains(&PresenceID{Node: "node-b", SessionID: sessionID}) {
+		t.Fatal("presence with matching SessionID but different Node must not be reported as present")
+	}
+}
+
+func TestMatchPresenceListJoinLeave(t *testing.T) {
+	userID := uuid.Must(uuid.NewV4())
+	sessionID := uuid.Must(uuid.NewV4())
+	presence := &MatchPresence{
+		Node:      "node-a",
+		UserID:    userID,
+		SessionID: sessionID,
+		Username:  "alice",
+	}
+
+	list := NewMatchPresenceList(4)
+	list.Join([]*MatchPresence{presence})
+
+	if !list.Contains(&PresenceID{Node: "node-a", SessionID: sessionID}) {
+		t.Fatal("expected presence to be found after join")
+	}
+	if !list.ContainsUserID(userID) {
+		t.Fatal("expected user to be found after join")
+	}
+	if len(list.List()) != 1 {
+		t.Fatalf("expected 1 presence in list, got %d", len(list.List()))
+	}
+
+	list.Leave([]*MatchPresence{presence})
+
+	if list.Contains(&PresenceID{Node: "node-a", SessionID: sessionID}) {
+		t.Fatal("expected presence to be gone after leave")
+	}
+	if list.ContainsUserID(userID) {
+		t.Fatal("expected user to be gone after leave")
+	}
+	if len(list.List()) != 0 {
+		t.Fatalf("expected 0 presences in list, got %d", len(list.List()))
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 The Nakama Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OverflowPolicyKind selects how a match queue behaves once it's full.
+type OverflowPolicyKind int
+
+const (
+	// OverflowDrop silently discards the new item, keeping whatever is already queued.
+	OverflowDrop OverflowPolicyKind = iota
+	// OverflowDropOldest evicts the longest-queued item to make room for the new one.
+	OverflowDropOldest
+	// OverflowBlock waits up to BlockTimeout for space to free up before giving up.
+	OverflowBlock
+	// OverflowCoalesce merges the new item into a same-opcode, same-session item already queued,
+	// via the caller-supplied Coalesce function. Only meaningful for the input queue.
+	OverflowCoalesce
+	// OverflowCloseMatch closes the match, preserving the historical "call queue full" behaviour.
+	OverflowCloseMatch
+)
+
+// OverflowPolicy configures what a single match queue does when it's full. BlockTimeout only
+// applies when Kind is OverflowBlock, and Coalesce only applies when Kind is OverflowCoalesce.
+type OverflowPolicy struct {
+	Kind         OverflowPolicyKind
+	BlockTimeout time.Duration
+	Coalesce     func(a, b *MatchDataMessage) *MatchDataMessage
+}
+
+// MatchQueueOverflowConfig carries the overflow policy for each of a match's queues, as optionally
+// returned from MatchInit. A nil *MatchQueueOverflowConfig at construction time falls back to
+// defaultMatchQueueOverflowConfig, which preserves the historical behaviour: the call queue
+// closes the match when full, and the input queue silently drops new messages.
+type MatchQueueOverflowConfig struct {
+	Call  OverflowPolicy
+	Input OverflowPolicy
+}
+
+var defaultMatchQueueOverflowConfig = MatchQueueOverflowConfig{
+	Call:  OverflowPolicy{Kind: OverflowCloseMatch},
+	Input: OverflowPolicy{Kind: OverflowDrop},
+}
+
+// resolveMatchQueueOverflowConfig normalises an optional overflow config from MatchInit, falling
+// back to defaultMatchQueueOverflowConfig when nil. It also guards against a config that would
+// panic at runtime: OverflowCoalesce on the input queue requires a Coalesce function, and a
+// MatchInit that sets the Kind without it is downgraded to OverflowDrop rather than crashing the
+// match the first time the queue fills.
+func resolveMatchQueueOverflowConfig(logger *zap.Logger, overflow *MatchQueueOverflowConfig) MatchQueueOverflowConfig {
+	if overflow == nil {
+		return defaultMatchQueueOverflowConfig
+	}
+
+	resolved := *overflow
+	if resolved.Input.Kind == OverflowCoalesce && resolved.Input.Coalesce == nil {
+		logger.Warn("Match input overflow policy is OverflowCoalesce with no Coalesce function set, falling back to OverflowDrop")
+		resolved.Input.Kind = OverflowDrop
+	}
+
+	return resolved
+}
+
+// MatchQueueMetrics is a point-in-time snapshot of a match's queue depths, lifetime drop/coalesce
+// counters, and current adaptive tick rate, for export through the metrics subsystem.
+type MatchQueueMetrics struct {
+	CallDepth  int
+	CallCap    int
+	CallDrops  int64
+	InputDepth int
+	InputCap   int
+	InputDrops int64
+	Coalesces  int64
+	TickRate   int
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 The Nakama Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestResolveMatchQueueOverflowConfigDefaults(t *testing.T) {
+	resolved := resolveMatchQueueOverflowConfig(zap.NewNop(), nil)
+
+	if resolved.Call.Kind != OverflowCloseMatch {
+		t.Fatalf("expected default call queue policy to be OverflowCloseMatch, got %v", resolved.Call.Kind)
+	}
+	if resolved.Input.Kind != OverflowDrop {
+		t.Fatalf("expected default input queue policy to be OverflowDrop, got %v", resolved.Input.Kind)
+	}
+}
+
+func TestResolveMatchQueueOverflowConfigOverride(t *testing.T) {
+	override := &MatchQueueOverflowConfig{
+		Call:  OverflowPolicy{Kind: OverflowDropOldest},
+		Input: OverflowPolicy{Kind: OverflowCoalesce, Coalesce: func(a, b *MatchDataMessage) *MatchDataMessage { return b }},
+	}
+
+	resolved := resolveMatchQueueOverflowConfig(zap.NewNop(), override)
+
+	if resolved.Call.Kind != OverflowDropOldest {
+		t.Fatalf("expected overridden call queue policy to be OverflowDropOldest, got %v", resolved.Call.Kind)
+	}
+	if resolved.Input.Kind != OverflowCoalesce {
+		t.Fatalf("expected overridden input queue policy to be OverflowCoalesce, got %v", resolved.Input.Kind)
+	}
+}
+
+func TestResolveMatchQueueOverflowConfigCoalesceWithoutFuncFallsBackToDrop(t *testing.T) {
+	override := &MatchQueueOverflowConfig{
+		Input: OverflowPolicy{Kind: OverflowCoalesce},
+	}
+
+	resolved := resolveMatchQueueOverflowConfig(zap.NewNop(), override)
+
+	if resolved.Input.Kind != OverflowDrop {
+		t.Fatalf("expected OverflowCoalesce with no Coalesce func to fall back to OverflowDrop, got %v", resolved.Input.Kind)
+	}
+}
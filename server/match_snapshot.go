@@ -0,0 +1,179 @@
+// Copyright 2018 The Nakama Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+// matchSnapshotBucket is the single bbolt bucket snapshots are stored in, keyed by IDStr.
+var matchSnapshotBucket = []byte("match_snapshots")
+
+// MatchSnapshotRecord is the durable record written for one match every snapshot_rate ticks.
+// Presences are deliberately not part of the record: on restore the match starts empty and
+// relies on its own MatchJoinAttempt/MatchJoin logic to decide whether to let players rejoin.
+type MatchSnapshotRecord struct {
+	State   []byte
+	Tick    int64
+	Label   string
+	Rate    int
+	SavedAt int64
+}
+
+// MatchSnapshotStore persists match snapshots to an embedded bbolt database so authoritative
+// match state can survive a node restart or drain, instead of dying with the process.
+type MatchSnapshotStore struct {
+	db *bolt.DB
+}
+
+// NewMatchSnapshotStore opens (creating if necessary) a bbolt database at path for match
+// snapshots.
+func NewMatchSnapshotStore(path string) (*MatchSnapshotStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening match snapshot store")
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(matchSnapshotBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "error initialising match snapshot bucket")
+	}
+
+	return &MatchSnapshotStore{db: db}, nil
+}
+
+func (s *MatchSnapshotStore) Close() error {
+	return s.db.Close()
+}
+
+// Save writes or overwrites the snapshot record for the match identified by idStr.
+func (s *MatchSnapshotStore) Save(idStr string, record *MatchSnapshotRecord) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(record); err != nil {
+		return errors.Wrap(err, "error encoding match snapshot")
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(matchSnapshotBucket).Put([]byte(idStr), buf.Bytes())
+	})
+}
+
+// Delete removes any snapshot stored for the match identified by idStr.
+func (s *MatchSnapshotStore) Delete(idStr string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(matchSnapshotBucket).Delete([]byte(idStr))
+	})
+}
+
+// ListFresherThan returns every stored snapshot saved more recently than now-staleness, keyed by
+// match IDStr. RestoreMatches uses this at server start to decide which matches are worth
+// recreating.
+func (s *MatchSnapshotStore) ListFresherThan(staleness time.Duration) (map[string]*MatchSnapshotRecord, error) {
+	cutoff := time.Now().Add(-staleness).Unix()
+	records := make(map[string]*MatchSnapshotRecord)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(matchSnapshotBucket).ForEach(func(k, v []byte) error {
+			var record MatchSnapshotRecord
+			if err := gob.NewDecoder(bytes.NewReader(v)).Decode(&record); err != nil {
+				return errors.Wrap(err, "error decoding match snapshot")
+			}
+			if record.SavedAt >= cutoff {
+				records[string(k)] = &record
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// MatchCoreFactory builds the RuntimeMatchCore a restored match should run against, for the match
+// identified by id. RestoreMatches calls this once per fresh snapshot found, since the core itself
+// (which module/runtime environment backs the match) isn't part of the persisted record.
+type MatchCoreFactory func(id uuid.UUID) (RuntimeMatchCore, error)
+
+// RestoreMatches re-creates a MatchHandler, via RestoreMatchHandler, for every snapshot saved more
+// recently than staleness. It is meant to be called once at server start, before the node starts
+// accepting new match joins, so authoritative matches survive a restart or drain instead of simply
+// vanishing with the process that ran them. A failure restoring any single match is logged and
+// otherwise ignored so one bad snapshot can't block the rest of the node's matches from recovering;
+// it returns the number of matches successfully restored.
+func RestoreMatches(logger *zap.Logger, config Config, matchRegistry MatchRegistry, snapshotStore *MatchSnapshotStore, parentCtx context.Context, node string, staleness time.Duration, coreFactory MatchCoreFactory) (int, error) {
+	records, err := snapshotStore.ListFresherThan(staleness)
+	if err != nil {
+		return 0, errors.Wrap(err, "error listing fresh match snapshots")
+	}
+
+	restored := 0
+	for idStr, record := range records {
+		id, recordNode, err := parseMatchIDStr(idStr)
+		if err != nil {
+			logger.Warn("Skipping match snapshot with unparseable ID", zap.String("id", idStr), zap.Error(err))
+			continue
+		}
+		if recordNode != node {
+			// This snapshot belongs to a different node in the cluster; leave it for that node to
+			// restore, rather than recreating the match here under the wrong node identity.
+			continue
+		}
+
+		core, err := coreFactory(id)
+		if err != nil {
+			logger.Warn("Skipping match snapshot, error building match core", zap.String("id", idStr), zap.Error(err))
+			continue
+		}
+
+		if _, err := RestoreMatchHandler(logger, config, matchRegistry, core, snapshotStore, parentCtx, id, node, record); err != nil {
+			logger.Warn("Error restoring match from snapshot", zap.String("id", idStr), zap.Error(err))
+			continue
+		}
+		restored++
+	}
+
+	return restored, nil
+}
+
+// parseMatchIDStr splits a MatchHandler.IDStr value ("<uuid>.<node>") back into its match ID and
+// node components.
+func parseMatchIDStr(idStr string) (uuid.UUID, string, error) {
+	parts := strings.SplitN(idStr, ".", 2)
+	if len(parts) != 2 {
+		return uuid.Nil, "", errors.Errorf("invalid match ID string %q", idStr)
+	}
+
+	id, err := uuid.FromString(parts[0])
+	if err != nil {
+		return uuid.Nil, "", errors.Wrap(err, "invalid match ID")
+	}
+
+	return id, parts[1], nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2018 The Nakama Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func newTestMatchSnapshotStore(t *testing.T) *MatchSnapshotStore {
+	t.Helper()
+
+	store, err := NewMatchSnapshotStore(filepath.Join(t.TempDir(), "match_snapshots.db"))
+	if err != nil {
+		t.Fatalf("error opening test match snapshot store: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+
+	return store
+}
+
+func TestMatchSnapshotStoreSaveAndListFresherThanRoundTrip(t *testing.T) {
+	store := newTestMatchSnapshotStore(t)
+
+	record := &MatchSnapshotRecord{State: []byte("state"), Tick: 42, Label: "label", Rate: 10, SavedAt: time.Now().Unix()}
+	if err := store.Save("match-1", record); err != nil {
+		t.Fatalf("error saving snapshot: %v", err)
+	}
+
+	fresh, err := store.ListFresherThan(time.Hour)
+	if err != nil {
+		t.Fatalf("error listing fresh snapshots: %v", err)
+	}
+
+	got, ok := fresh["match-1"]
+	if !ok {
+		t.Fatalf("expected saved snapshot to be listed, got %+v", fresh)
+	}
+	if got.Tick != record.Tick || got.Label != record.Label || got.Rate != record.Rate || string(got.State) != string(record.State) {
+		t.Fatalf("round-tripped record %+v does not match saved record %+v", got, record)
+	}
+}
+
+func TestMatchSnapshotStoreDeleteRemovesRecord(t *testing.T) {
+	store := newTestMatchSnapshotStore(t)
+
+	record := &MatchSnapshotRecord{State: []byte("state"), SavedAt: time.Now().Unix()}
+	if err := store.Save("match-1", record); err != nil {
+		t.Fatalf("error saving snapshot: %v", err)
+	}
+	if err := store.Delete("match-1"); err != nil {
+		t.Fatalf("error deleting snapshot: %v", err)
+	}
+
+	fresh, err := store.ListFresherThan(time.Hour)
+	if err != nil {
+		t.Fatalf("error listing fresh snapshots: %v", err)
+	}
+	if _, ok := fresh["match-1"]; ok {
+		t.Fatalf("expected deleted snapshot to be gone, still listed: %+v", fresh)
+	}
+}
+
+func TestMatchSnapshotStoreListFresherThanCutoffBoundary(t *testing.T) {
+	store := newTestMatchSnapshotStore(t)
+	staleness := time.Hour
+	now := time.Now()
+
+	fresh := &MatchSnapshotRecord{State: []byte("fresh"), SavedAt: now.Unix()}
+	stale := &MatchSnapshotRecord{State: []byte("stale"), SavedAt: now.Add(-staleness - time.Minute).Unix()}
+	if err := store.Save("fresh-match", fresh); err != nil {
+		t.Fatalf("error saving fresh snapshot: %v", err)
+	}
+	if err := store.Save("stale-match", stale); err != nil {
+		t.Fatalf("error saving stale snapshot: %v", err)
+	}
+
+	records, err := store.ListFresherThan(staleness)
+	if err != nil {
+		t.Fatalf("error listing fresh snapshots: %v", err)
+	}
+
+	if _, ok := records["fresh-match"]; !ok {
+		t.Fatalf("expected fresh-match to be within the staleness window, got %+v", records)
+	}
+	if _, ok := records["stale-match"]; ok {
+		t.Fatalf("expected stale-match to be excluded as older than the staleness window, got %+v", records)
+	}
+}
+
+func TestParseMatchIDStr(t *testing.T) {
+	id := uuid.Must(uuid.NewV4())
+
+	gotID, gotNode, err := parseMatchIDStr(id.String() + ".node-a")
+	if err != nil {
+		t.Fatalf("unexpected error parsing valid ID string: %v", err)
+	}
+	if gotID != id {
+		t.Fatalf("expected parsed ID %v, got %v", id, gotID)
+	}
+	if gotNode != "node-a" {
+		t.Fatalf("expected parsed node %q, got %q", "node-a", gotNode)
+	}
+
+	if _, _, err := parseMatchIDStr("not-a-valid-id-string"); err == nil {
+		t.Fatalf("expected an error parsing a malformed ID string")
+	}
+	if _, _, err := parseMatchIDStr("not-a-uuid.node-a"); err == nil {
+		t.Fatalf("expected an error parsing an ID string with an invalid UUID")
+	}
+}
+
+func TestRestoreMatchesSkipsOtherNodesAndUnparseableIDs(t *testing.T) {
+	store := newTestMatchSnapshotStore(t)
+	localNode := "node-a"
+	now := time.Now().Unix()
+
+	localID := uuid.Must(uuid.NewV4())
+	remoteID := uuid.Must(uuid.NewV4())
+
+	if err := store.Save(localID.String()+"."+localNode, &MatchSnapshotRecord{SavedAt: now}); err != nil {
+		t.Fatalf("error saving local snapshot: %v", err)
+	}
+	if err := store.Save(remoteID.String()+".node-b", &MatchSnapshotRecord{SavedAt: now}); err != nil {
+		t.Fatalf("error saving remote snapshot: %v", err)
+	}
+	if err := store.Save("not-a-valid-id-string", &MatchSnapshotRecord{SavedAt: now}); err != nil {
+		t.Fatalf("error saving unparseable snapshot: %v", err)
+	}
+
+	var factoryCalls []uuid.UUID
+	coreFactory := func(id uuid.UUID) (RuntimeMatchCore, error) {
+		factoryCalls = append(factoryCalls, id)
+		// Fail deliberately so the test never needs a real MatchRegistry/RuntimeMatchCore/Config:
+		// RestoreMatches must have already filtered by node/ID before reaching this point.
+		return nil, errors.New("deliberate test failure")
+	}
+
+	restored, err := RestoreMatches(zap.NewNop(), nil, nil, store, context.Background(), localNode, time.Hour, coreFactory)
+	if err != nil {
+		t.Fatalf("unexpected error from RestoreMatches: %v", err)
+	}
+	if restored != 0 {
+		t.Fatalf("expected 0 matches restored since coreFactory always fails, got %d", restored)
+	}
+
+	if len(factoryCalls) != 1 || factoryCalls[0] != localID {
+		t.Fatalf("expected coreFactory to be called exactly once, for the local-node match %v, got %v", localID, factoryCalls)
+	}
+}